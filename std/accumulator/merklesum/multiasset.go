@@ -0,0 +1,104 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merklesum
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash"
+)
+
+// MultiAssetMerkleSumProof is a MerkleSumProof that aggregates a vector of
+// per-asset sums instead of a single one, so a single tree can attest to an
+// exchange's liabilities across several supported assets at once.
+type MultiAssetMerkleSumProof struct {
+
+	// RootHash is the root hash of the Merkle tree.
+	RootHash frontend.Variable
+
+	// RootSums holds the aggregated sum of each asset, RootSums[j] being
+	// the total liability in asset j.
+	RootSums []frontend.Variable
+
+	// PathHash[0] is the proven leaf's hash (used as is, not hashed again)
+	// and PathHash[i], i > 0, is the sibling hash at level i.
+	PathHash []frontend.Variable
+
+	// PathSums holds, for every level of the path, a balance in every
+	// asset: PathSums[0][j] is the proven leaf's balance in asset j and
+	// PathSums[i][j], i > 0, the one carried by the sibling at level i.
+	PathSums [][]frontend.Variable
+
+	// bitsPerAsset[j] bounds the leaf's balance PathSums[0][j] to that many
+	// bits, and at level i the sibling's PathSums[i][j] to bits+i-1 and the
+	// running aggregate to bits+i, so each asset gets its own
+	// overflow/negativity check.
+	bitsPerAsset []int
+}
+
+// NewMultiAssetMerkleSumProof allocates a MultiAssetMerkleSumProof for a
+// tree of the given depth and number of assets, with bitsPerAsset[j]
+// bounding every balance in asset j.
+func NewMultiAssetMerkleSumProof(depth, numAssets int, bitsPerAsset []int) MultiAssetMerkleSumProof {
+
+	pathSums := make([][]frontend.Variable, depth+1)
+	for i := range pathSums {
+		pathSums[i] = make([]frontend.Variable, numAssets)
+	}
+
+	return MultiAssetMerkleSumProof{
+		RootSums:     make([]frontend.Variable, numAssets),
+		PathHash:     make([]frontend.Variable, depth+1),
+		PathSums:     pathSums,
+		bitsPerAsset: bitsPerAsset,
+	}
+}
+
+// VerifyProof reconstructs the tree's hash and, independently, each asset's
+// aggregated sum along the path to leaf.data, and checks them against
+// RootHash/RootSums.
+func (mp *MultiAssetMerkleSumProof) VerifyProof(api frontend.API, h hash.Hash, leaf Leaf) {
+
+	depth := len(mp.PathHash) - 1
+	hash := mp.PathHash[0]
+
+	sums := make([]frontend.Variable, len(mp.bitsPerAsset))
+	for j, bits := range mp.bitsPerAsset {
+		api.ToBinary(mp.PathSums[0][j], bits)
+		sums[j] = mp.PathSums[0][j]
+	}
+
+	binLeaf := api.ToBinary(leaf.data, depth)
+
+	for i := 1; i < len(mp.PathHash); i++ {
+		d1 := api.Select(binLeaf[i-1], mp.PathHash[i], hash)
+		d2 := api.Select(binLeaf[i-1], hash, mp.PathHash[i])
+		hash = nodeHash(api, h, d1, d2)
+
+		for j, bits := range mp.bitsPerAsset {
+			// PathSums[i][j] is the sibling subtree's sum over its
+			// 2^(i-1) leaves, so it needs bits+(i-1) bits, not just bits.
+			api.ToBinary(mp.PathSums[i][j], bits+i-1)
+			sums[j] = nodeSum(api, sums[j], mp.PathSums[i][j])
+			api.ToBinary(sums[j], bits+i)
+		}
+	}
+
+	api.AssertIsEqual(hash, mp.RootHash)
+	for j := range sums {
+		api.AssertIsEqual(sums[j], mp.RootSums[j])
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merklesum
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	gcHash "github.com/consensys/gnark-crypto/hash"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/accumulator/merklesum/plain"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/test"
+)
+
+// MultiAssetMerkleProofTest used for testing only
+type MultiAssetMerkleProofTest struct {
+	M     MultiAssetMerkleSumProof
+	Index frontend.Variable
+}
+
+func (mp *MultiAssetMerkleProofTest) Define(api frontend.API) error {
+
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	mp.M.VerifyProof(api, &h, Leaf{data: mp.Index})
+
+	return nil
+}
+
+func TestMultiAssetVerify(t *testing.T) {
+
+	assert := test.NewAssert(t)
+	const (
+		numLeaves   = 8
+		depth       = 3
+		numAssets   = 2
+		proofIndex  = 3
+		bitsPerAsst = 64
+	)
+	curveID := ecc.BN254
+	bitsPerAsset := []int{bitsPerAsst, bitsPerAsst}
+
+	leaves := make([]plain.MultiAssetLeaf, numLeaves)
+	for i := range leaves {
+		h, err := rand.Int(rand.Reader, curveID.ScalarField())
+		assert.NoError(err)
+		balances := make([]uint64, numAssets)
+		for j := range balances {
+			b, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), bitsPerAsst))
+			assert.NoError(err)
+			balances[j] = b.Uint64()
+		}
+		leaves[i] = plain.MultiAssetLeaf{Hash: h, Balances: balances}
+	}
+
+	hFunc := gcHash.MIMC_BN254.New()
+	rootHash, rootSums, path, err := plain.BuildMultiAssetRoot(curveID, hFunc, leaves, numAssets, proofIndex)
+	assert.NoError(err)
+
+	var circuit MultiAssetMerkleProofTest
+	circuit.M = NewMultiAssetMerkleSumProof(depth, numAssets, bitsPerAsset)
+
+	var witness MultiAssetMerkleProofTest
+	witness.M = NewMultiAssetMerkleSumProof(depth, numAssets, bitsPerAsset)
+	witness.Index = proofIndex
+	witness.M.RootHash = rootHash
+	for j := 0; j < numAssets; j++ {
+		witness.M.RootSums[j] = rootSums[j]
+	}
+	for i := 0; i <= depth; i++ {
+		witness.M.PathHash[i] = path.Hash[i]
+		for j := 0; j < numAssets; j++ {
+			witness.M.PathSums[i][j] = path.Sums[i][j]
+		}
+	}
+
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(curveID))
+}
@@ -0,0 +1,140 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merklesum
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash"
+)
+
+// MultiMerkleSumProof proves inclusion of K distinct leaves under a single
+// root in one pass, sharing the hashing of any ancestor common to several of
+// the leaves instead of recomputing it once per leaf. Unlike MerkleSumProof,
+// the set of leaf indices is fixed when the circuit is built (it decides
+// which ancestors are shared, and therefore the circuit's shape), not part
+// of the witness.
+type MultiMerkleSumProof struct {
+
+	// RootHash, RootSum is the root of the Merkle sum tree.
+	RootHash, RootSum frontend.Variable
+
+	// LeafHash, LeafSum hold, in the same order as indices, every proven
+	// leaf's hash and balance.
+	LeafHash, LeafSum []frontend.Variable
+
+	// SiblingHash, SiblingSum hold the minimal stream of siblings needed to
+	// recompute the root once: one entry for every node on the leaves'
+	// paths to the root whose tree sibling is *not* itself on one of those
+	// paths.
+	SiblingHash, SiblingSum []frontend.Variable
+
+	// indices are the K sorted leaf indices this proof was built for, and
+	// depth is the tree depth; both fixed at circuit-build time since they
+	// determine which siblings come from the queue versus the proof stream.
+	indices []int
+	depth   int
+
+	balanceBits int
+}
+
+// NewMultiMerkleSumProof allocates a MultiMerkleSumProof for a tree of the
+// given depth, proving inclusion of the leaves at the given sorted indices,
+// with balances bounded to balanceBits bits. nbSiblings is the size of the
+// minimal sibling stream for this index set, as returned by
+// plain.BuildMultiProof.
+func NewMultiMerkleSumProof(depth, balanceBits int, indices []int, nbSiblings int) MultiMerkleSumProof {
+	return MultiMerkleSumProof{
+		LeafHash:    make([]frontend.Variable, len(indices)),
+		LeafSum:     make([]frontend.Variable, len(indices)),
+		SiblingHash: make([]frontend.Variable, nbSiblings),
+		SiblingSum:  make([]frontend.Variable, nbSiblings),
+		indices:     indices,
+		depth:       depth,
+		balanceBits: balanceBits,
+	}
+}
+
+// multiProofNode is a (index, hash, sum) triple tracked while climbing the
+// tree from the proven leaves up to the root.
+type multiProofNode struct {
+	index int
+	hash  frontend.Variable
+	sum   frontend.Variable
+}
+
+// VerifyProof reconstructs the root from the K leaves and the minimal
+// sibling stream: at every level, a node's sibling is taken from its
+// neighbor still in the queue if that neighbor is its actual tree sibling,
+// or from the next unconsumed entry of the sibling stream otherwise.
+func (mp *MultiMerkleSumProof) VerifyProof(api frontend.API, h hash.Hash) {
+
+	queue := make([]multiProofNode, len(mp.indices))
+	for i, idx := range mp.indices {
+		api.ToBinary(mp.LeafSum[i], mp.balanceBits)
+		queue[i] = multiProofNode{index: idx, hash: mp.LeafHash[i], sum: mp.LeafSum[i]}
+	}
+
+	siblingPos := 0
+	for level := 0; level < mp.depth; level++ {
+
+		next := make([]multiProofNode, 0, (len(queue)+1)/2)
+
+		for i := 0; i < len(queue); {
+			cur := queue[i]
+
+			var left, right multiProofNode
+			if i+1 < len(queue) && queue[i+1].index == cur.index^1 {
+				// the sibling is the neighbor already waiting in the queue.
+				if cur.index%2 == 0 {
+					left, right = cur, queue[i+1]
+				} else {
+					left, right = queue[i+1], cur
+				}
+				i += 2
+			} else {
+				// the sibling comes from the minimal proof stream: it sits
+				// at this level, covering 2^level leaves, so it needs
+				// balanceBits+level bits, not just balanceBits.
+				api.ToBinary(mp.SiblingSum[siblingPos], mp.balanceBits+level)
+				sibling := multiProofNode{
+					index: cur.index ^ 1,
+					hash:  mp.SiblingHash[siblingPos],
+					sum:   mp.SiblingSum[siblingPos],
+				}
+				siblingPos++
+
+				if cur.index%2 == 0 {
+					left, right = cur, sibling
+				} else {
+					left, right = sibling, cur
+				}
+				i++
+			}
+
+			parentHash := nodeHash(api, h, left.hash, right.hash)
+			parentSum := nodeSum(api, left.sum, right.sum)
+			api.ToBinary(parentSum, mp.balanceBits+level+1)
+
+			next = append(next, multiProofNode{index: cur.index / 2, hash: parentHash, sum: parentSum})
+		}
+
+		queue = next
+	}
+
+	api.AssertIsEqual(queue[0].hash, mp.RootHash)
+	api.AssertIsEqual(queue[0].sum, mp.RootSum)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merklesum
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	gcHash "github.com/consensys/gnark-crypto/hash"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/std/accumulator/merklesum/plain"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/test"
+)
+
+const (
+	multiProofNumLeaves   = 16
+	multiProofDepth       = 4
+	multiProofBalanceBits = 64
+)
+
+// MultiMerkleProofTest used for testing only
+type MultiMerkleProofTest struct {
+	M MultiMerkleSumProof
+}
+
+func (mp *MultiMerkleProofTest) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	mp.M.VerifyProof(api, &h)
+	return nil
+}
+
+func buildRandomLeaves(assert *test.Assert, curveID ecc.ID) ([]*big.Int, []*big.Int) {
+	leafHash := make([]*big.Int, multiProofNumLeaves)
+	leafSum := make([]*big.Int, multiProofNumLeaves)
+	maxBalance := new(big.Int).Lsh(big.NewInt(1), multiProofBalanceBits)
+	for i := range leafHash {
+		h, err := rand.Int(rand.Reader, curveID.ScalarField())
+		assert.NoError(err)
+		s, err := rand.Int(rand.Reader, maxBalance)
+		assert.NoError(err)
+		leafHash[i], leafSum[i] = h, s
+	}
+	return leafHash, leafSum
+}
+
+// TestMultiVerify checks that a batch of leaves, some of them sharing
+// ancestors and one far from the others, verifies correctly against the root.
+func TestMultiVerify(t *testing.T) {
+
+	assert := test.NewAssert(t)
+	curveID := ecc.BN254
+	indices := []int{2, 3, 4, 9}
+
+	leafHash, leafSum := buildRandomLeaves(assert, curveID)
+	hashLevels, sumLevels, err := plain.BuildTree(curveID, gcHash.MIMC_BN254.New(), leafHash, leafSum)
+	assert.NoError(err)
+	proof := plain.BuildMultiProof(hashLevels, sumLevels, indices)
+
+	var circuit MultiMerkleProofTest
+	circuit.M = NewMultiMerkleSumProof(multiProofDepth, multiProofBalanceBits, indices, len(proof.SiblingHash))
+
+	var witness MultiMerkleProofTest
+	witness.M = NewMultiMerkleSumProof(multiProofDepth, multiProofBalanceBits, indices, len(proof.SiblingHash))
+	witness.M.RootHash = hashLevels[multiProofDepth][0]
+	witness.M.RootSum = sumLevels[multiProofDepth][0]
+	for i := range indices {
+		witness.M.LeafHash[i] = proof.LeafHash[i]
+		witness.M.LeafSum[i] = proof.LeafSum[i]
+	}
+	for i := range proof.SiblingHash {
+		witness.M.SiblingHash[i] = proof.SiblingHash[i]
+		witness.M.SiblingSum[i] = proof.SiblingSum[i]
+	}
+
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(curveID))
+}
+
+// TestMultiVerifyConstraintCount checks that batching K inclusion proofs
+// that share ancestors costs fewer constraints than K separate
+// MerkleSumProof.VerifyProof calls against the same tree.
+func TestMultiVerifyConstraintCount(t *testing.T) {
+
+	assert := test.NewAssert(t)
+	curveID := ecc.BN254
+	indices := []int{2, 3, 4, 5, 6, 7}
+
+	leafHash, leafSum := buildRandomLeaves(assert, curveID)
+	hashLevels, sumLevels, err := plain.BuildTree(curveID, gcHash.MIMC_BN254.New(), leafHash, leafSum)
+	assert.NoError(err)
+	proof := plain.BuildMultiProof(hashLevels, sumLevels, indices)
+
+	var multiCircuit MultiMerkleProofTest
+	multiCircuit.M = NewMultiMerkleSumProof(multiProofDepth, multiProofBalanceBits, indices, len(proof.SiblingHash))
+	multiCcs, err := frontend.Compile(curveID.ScalarField(), r1cs.NewBuilder, &multiCircuit)
+	assert.NoError(err)
+
+	var singleCcs frontend.CompiledConstraintSystem
+	totalSingle := 0
+	for range indices {
+		var c MerkleProofTest
+		c.M = NewMerkleSumProof(multiProofDepth, multiProofBalanceBits)
+		singleCcs, err = frontend.Compile(curveID.ScalarField(), r1cs.NewBuilder, &c)
+		assert.NoError(err)
+		totalSingle += singleCcs.GetNbConstraints()
+	}
+
+	if multiCcs.GetNbConstraints() >= totalSingle {
+		t.Fatalf("expected batched proof (%d constraints) to cost less than %d independent proofs (%d constraints)",
+			multiCcs.GetNbConstraints(), len(indices), totalSingle)
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plain
+
+import (
+	"fmt"
+	"hash"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// MultiAssetLeaf is a leaf of a multi-asset Merkle sum tree: a hash together
+// with a balance per supported asset.
+type MultiAssetLeaf struct {
+	Hash     *big.Int
+	Balances []uint64
+}
+
+// MultiAssetProofPath is the sibling hash/sums on the path from a leaf to
+// the root of a multi-asset Merkle sum tree, as produced by
+// BuildMultiAssetRoot.
+type MultiAssetProofPath struct {
+	Hash []*big.Int
+	Sums [][]*big.Int
+}
+
+// BuildMultiAssetRoot computes the root hash and per-asset root sums of the
+// tree formed by leaves, and the proof path for proofIndex. len(leaves) must
+// be a power of two.
+func BuildMultiAssetRoot(curve ecc.ID, hFunc hash.Hash, leaves []MultiAssetLeaf, numAssets int, proofIndex int) (rootHash *big.Int, rootSums []*big.Int, path MultiAssetProofPath, err error) {
+
+	modNbBytes := len(curve.ScalarField().Bytes())
+
+	n := len(leaves)
+	if n&(n-1) != 0 || n == 0 {
+		return nil, nil, path, fmt.Errorf("number of leaves (%d) must be a power of 2", n)
+	}
+	if proofIndex < 0 || proofIndex >= n {
+		return nil, nil, path, fmt.Errorf("proof index %d out of range for %d leaves", proofIndex, n)
+	}
+
+	depth := 0
+	for 1<<depth < n {
+		depth++
+	}
+
+	hashes := make([]*big.Int, n)
+	sums := make([][]*big.Int, n)
+	for i, l := range leaves {
+		hashes[i] = l.Hash
+		sums[i] = make([]*big.Int, numAssets)
+		for j := 0; j < numAssets; j++ {
+			sums[i][j] = new(big.Int).SetUint64(l.Balances[j])
+		}
+	}
+
+	path.Hash = make([]*big.Int, depth+1)
+	path.Sums = make([][]*big.Int, depth+1)
+	idx := proofIndex
+	path.Hash[0] = hashes[idx]
+	path.Sums[0] = sums[idx]
+
+	for level := 0; level < depth; level++ {
+		siblingIdx := idx ^ 1
+		path.Hash[level+1] = hashes[siblingIdx]
+		path.Sums[level+1] = sums[siblingIdx]
+
+		nextHashes := make([]*big.Int, len(hashes)/2)
+		nextSums := make([][]*big.Int, len(hashes)/2)
+		for i := 0; i < len(hashes); i += 2 {
+			hFunc.Reset()
+			hFunc.Write(fieldBytes(modNbBytes, hashes[i]))
+			hFunc.Write(fieldBytes(modNbBytes, hashes[i+1]))
+			nextHashes[i/2] = new(big.Int).SetBytes(hFunc.Sum(nil))
+
+			nextSums[i/2] = make([]*big.Int, numAssets)
+			for j := 0; j < numAssets; j++ {
+				nextSums[i/2][j] = new(big.Int).Add(sums[i][j], sums[i+1][j])
+			}
+		}
+
+		hashes, sums = nextHashes, nextSums
+		idx /= 2
+	}
+
+	return hashes[0], sums[0], path, nil
+}
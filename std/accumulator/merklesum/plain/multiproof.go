@@ -0,0 +1,116 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plain
+
+import (
+	"fmt"
+	"hash"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// MultiProof is the minimal data a MultiMerkleSumProof circuit needs to
+// recompute a root from several leaves at once: the leaves themselves
+// (ordered like the requested indices) and the deduplicated sibling stream
+// consumed level by level.
+type MultiProof struct {
+	LeafHash []*big.Int
+	LeafSum  []*big.Int
+
+	SiblingHash []*big.Int
+	SiblingSum  []*big.Int
+}
+
+// BuildTree computes every level of the Merkle sum tree over leafHash/
+// leafSum (len(leafHash) must be a power of two), from the leaves
+// (level 0) up to the root (the single node of the last level).
+func BuildTree(curve ecc.ID, hFunc hash.Hash, leafHash, leafSum []*big.Int) (hashLevels, sumLevels [][]*big.Int, err error) {
+
+	modNbBytes := len(curve.ScalarField().Bytes())
+
+	n := len(leafHash)
+	if n == 0 || n&(n-1) != 0 {
+		return nil, nil, fmt.Errorf("number of leaves (%d) must be a power of 2", n)
+	}
+
+	hashLevels = [][]*big.Int{leafHash}
+	sumLevels = [][]*big.Int{leafSum}
+
+	curHash, curSum := leafHash, leafSum
+	for len(curHash) > 1 {
+		nextHash := make([]*big.Int, len(curHash)/2)
+		nextSum := make([]*big.Int, len(curHash)/2)
+		for i := 0; i < len(curHash); i += 2 {
+			hFunc.Reset()
+			hFunc.Write(fieldBytes(modNbBytes, curHash[i]))
+			hFunc.Write(fieldBytes(modNbBytes, curHash[i+1]))
+			nextHash[i/2] = new(big.Int).SetBytes(hFunc.Sum(nil))
+			nextSum[i/2] = new(big.Int).Add(curSum[i], curSum[i+1])
+		}
+		hashLevels = append(hashLevels, nextHash)
+		sumLevels = append(sumLevels, nextSum)
+		curHash, curSum = nextHash, nextSum
+	}
+
+	return hashLevels, sumLevels, nil
+}
+
+// BuildMultiProof returns the minimal MultiProof covering the given sorted,
+// distinct leaf indices against a tree whose levels were computed by
+// BuildTree: it walks the same "pop from the queue or from the stream"
+// algorithm the circuit uses, so the two stay in lock-step.
+func BuildMultiProof(hashLevels, sumLevels [][]*big.Int, indices []int) MultiProof {
+
+	depth := len(hashLevels) - 1
+
+	type node struct {
+		index int
+		hash  *big.Int
+		sum   *big.Int
+	}
+
+	queue := make([]node, len(indices))
+	proof := MultiProof{
+		LeafHash: make([]*big.Int, len(indices)),
+		LeafSum:  make([]*big.Int, len(indices)),
+	}
+	for i, idx := range indices {
+		queue[i] = node{idx, hashLevels[0][idx], sumLevels[0][idx]}
+		proof.LeafHash[i] = hashLevels[0][idx]
+		proof.LeafSum[i] = sumLevels[0][idx]
+	}
+
+	for level := 0; level < depth; level++ {
+		var next []node
+		for i := 0; i < len(queue); {
+			cur := queue[i]
+			if i+1 < len(queue) && queue[i+1].index == cur.index^1 {
+				i += 2
+			} else {
+				siblingIdx := cur.index ^ 1
+				proof.SiblingHash = append(proof.SiblingHash, hashLevels[level][siblingIdx])
+				proof.SiblingSum = append(proof.SiblingSum, sumLevels[level][siblingIdx])
+				i++
+			}
+			next = append(next, node{index: cur.index / 2})
+		}
+		queue = next
+	}
+
+	return proof
+}
@@ -0,0 +1,175 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package plain builds Merkle sum trees and witnesses for the
+// Proof-of-Solvency circuit in plain Go, outside of any circuit, and runs
+// the circuit's Groth16 setup. It does not create or verify Groth16 proofs.
+package plain
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"strconv"
+
+	merkleSum "github.com/consensys/gnark-crypto/accumulator/merklesumtree"
+	"github.com/consensys/gnark-crypto/ecc"
+	gcHash "github.com/consensys/gnark-crypto/hash"
+	"github.com/consensys/gnark/backend/groth16"
+	eddsaBN256 "github.com/consensys/gnark/crypto/signature/eddsa/bn256"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/std/accumulator/merklesum"
+)
+
+// Account is a single row of the exchange's published liabilities: a user's
+// public key together with the balance the exchange claims to owe them.
+type Account struct {
+	PublicKey eddsaBN256.PublicKey
+	Balance   uint64
+}
+
+// ParseAccountsCSV reads rows of "pubkey_hex,balance" from r into Accounts.
+func ParseAccountsCSV(r io.Reader) ([]Account, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]Account, len(rows))
+	for i, row := range rows {
+		if len(row) != 2 {
+			return nil, fmt.Errorf("row %d: expected 2 columns, got %d", i, len(row))
+		}
+
+		pubBytes, err := hex.DecodeString(row[0])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i, err)
+		}
+
+		var pub eddsaBN256.PublicKey
+		if _, err := pub.SetBytes(pubBytes); err != nil {
+			return nil, fmt.Errorf("row %d: %w", i, err)
+		}
+
+		balance, err := strconv.ParseUint(row[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i, err)
+		}
+
+		accounts[i] = Account{PublicKey: pub, Balance: balance}
+	}
+
+	return accounts, nil
+}
+
+// pubKeyLeafData returns MiMC(X, Y) of pub, the same hash SolvencyCircuit's
+// Define asserts against the leaf's stored data, so a tree built here and a
+// circuit verifying against it agree on what a user's leaf contains.
+func pubKeyLeafData(hFunc hash.Hash, pub eddsaBN256.PublicKey) []byte {
+	hFunc.Reset()
+	hFunc.Write(pub.A.X.Bytes())
+	hFunc.Write(pub.A.Y.Bytes())
+	return hFunc.Sum(nil)
+}
+
+// BuildTree lays out accounts as the leaves of a Merkle sum tree (leaf data
+// is MiMC(X, Y) of the account's public key, leaf balance is its claimed
+// balance) and returns the tree's root. segmentSize is the per-leaf chunk
+// size expected by merkleSum.BuildReaderProof.
+//
+// merkleSum.BuildReaderProof itself has no range check on the balances it is
+// given, so BuildTree rejects any balance that would not fit in balanceBits
+// bits before it ever reaches gnark-crypto, keeping the off-circuit tree
+// within the same bound SolvencyCircuit enforces in-circuit.
+func BuildTree(curve ecc.ID, accounts []Account, balanceBits, segmentSize int) (merkleSum.MerkleRoot, []byte, []byte, error) {
+	mod := curve.ScalarField()
+	modNbBytes := len(mod.Bytes())
+	maxBalance := new(big.Int).Lsh(big.NewInt(1), uint(balanceBits))
+
+	hFunc := gcHash.MIMC_BN254.New()
+	var data, sums bytes.Buffer
+	for i, a := range accounts {
+		if new(big.Int).SetUint64(a.Balance).Cmp(maxBalance) >= 0 {
+			return merkleSum.MerkleRoot{}, nil, nil, fmt.Errorf("account %d: balance %d does not fit in %d bits", i, a.Balance, balanceBits)
+		}
+
+		leafData := pubKeyLeafData(hFunc, a.PublicKey)
+		data.Write(make([]byte, modNbBytes-len(leafData)))
+		data.Write(leafData)
+
+		b := new(big.Int).SetUint64(a.Balance).Bytes()
+		sums.Write(make([]byte, modNbBytes-len(b)))
+		sums.Write(b)
+	}
+
+	root, _, _, err := merkleSum.BuildReaderProof(bytes.NewReader(data.Bytes()), bytes.NewReader(sums.Bytes()), gcHash.MIMC_BN254.New(), segmentSize, 0)
+	return root, data.Bytes(), sums.Bytes(), err
+}
+
+// BuildWitness produces a full SolvencyCircuit witness for accounts, signing
+// nonce with each of the corresponding privateKeys.
+func BuildWitness(curve ecc.ID, accounts []Account, privateKeys []eddsaBN256.PrivateKey, nonce uint64, depth, balanceBits int, data, sums []byte, segmentSize int) (*merklesum.SolvencyCircuit, error) {
+
+	witness := merklesum.NewSolvencyCircuit(len(accounts), depth, balanceBits)
+	witness.Nonce = nonce
+
+	hFunc := gcHash.MIMC_BN254.New()
+	nonceBytes := new(big.Int).SetUint64(nonce).Bytes()
+
+	for i := range accounts {
+		root, proofPath, numLeaves, err := merkleSum.BuildReaderProof(bytes.NewReader(data), bytes.NewReader(sums), gcHash.MIMC_BN254.New(), segmentSize, uint64(i))
+		if err != nil {
+			return nil, err
+		}
+		_ = numLeaves
+
+		witness.RootHash = root.Hash
+		witness.RootSum = root.Sum
+		witness.Index[i] = uint64(i)
+		for j := 0; j <= depth; j++ {
+			witness.Proofs[i].PathHash[j] = proofPath.Hash[j]
+			witness.Proofs[i].PathSum[j] = proofPath.Sum[j]
+		}
+
+		hFunc.Reset()
+		sig, err := privateKeys[i].Sign(nonceBytes, hFunc)
+		if err != nil {
+			return nil, err
+		}
+
+		witness.PubKeys[i].Assign(curve, accounts[i].PublicKey.A)
+		witness.Signatures[i].Assign(curve, sig)
+	}
+
+	return &witness, nil
+}
+
+// Setup runs the Groth16 setup for a SolvencyCircuit sized for k users of
+// the given tree depth and balance bit-width.
+func Setup(curve ecc.ID, k, depth, balanceBits int) (groth16.ProvingKey, groth16.VerifyingKey, frontend.CompiledConstraintSystem, error) {
+	circuit := merklesum.NewSolvencyCircuit(k, depth, balanceBits)
+	ccs, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, &circuit)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	return pk, vk, ccs, err
+}
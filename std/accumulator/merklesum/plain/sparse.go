@@ -0,0 +1,51 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plain
+
+import (
+	"hash"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+)
+
+// EmptySubtreeConstants returns, for every depth from the leaf (index 0) up
+// to the root (index depth), the hash/sum of an empty subtree rooted at
+// that depth, for the given curve and hash function. These are fixed once
+// curve and hFunc are fixed, and can be embedded as public circuit
+// constants.
+func EmptySubtreeConstants(curve ecc.ID, hFunc hash.Hash, depth int) (emptyHash, emptySum []*big.Int) {
+
+	modNbBytes := len(curve.ScalarField().Bytes())
+
+	emptyHash = make([]*big.Int, depth+1)
+	emptySum = make([]*big.Int, depth+1)
+
+	emptyHash[0] = new(big.Int)
+	emptySum[0] = new(big.Int)
+
+	for i := 1; i <= depth; i++ {
+		hFunc.Reset()
+		b := fieldBytes(modNbBytes, emptyHash[i-1])
+		hFunc.Write(b)
+		hFunc.Write(b)
+		emptyHash[i] = new(big.Int).SetBytes(hFunc.Sum(nil))
+		emptySum[i] = new(big.Int).Add(emptySum[i-1], emptySum[i-1])
+	}
+
+	return emptyHash, emptySum
+}
@@ -0,0 +1,32 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plain
+
+import "math/big"
+
+// fieldBytes serializes x to a fixed modNbBytes-wide big-endian block,
+// left-padded with zeros. *big.Int.Bytes returns the minimal encoding, which
+// is shorter than modNbBytes whenever x has leading zero bytes; hashing that
+// minimal encoding diverges from a circuit's nodeHash, which always absorbs
+// full field elements. Every plain-Go tree builder must pad with fieldBytes
+// before writing a hash or sum into hFunc so the two stay in lock-step.
+func fieldBytes(modNbBytes int, x *big.Int) []byte {
+	b := x.Bytes()
+	padded := make([]byte, modNbBytes)
+	copy(padded[modNbBytes-len(b):], b)
+	return padded
+}
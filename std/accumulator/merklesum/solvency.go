@@ -0,0 +1,91 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merklesum
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/signature/eddsa"
+)
+
+// SolvencyCircuit proves, for a batch of users, that an exchange's published
+// liabilities (RootHash/RootSum) are backed by balances the users themselves
+// attest to: for every user it checks an EdDSA signature of Nonce under the
+// user's public key, a MerkleSum inclusion proof of that user's balance
+// under RootHash/RootSum, and that the included balance is non-negative and
+// bounded (enforced by MerkleSumProof.VerifyProof itself).
+type SolvencyCircuit struct {
+
+	// RootHash, RootSum are the exchange's published Merkle sum tree root.
+	RootHash, RootSum frontend.Variable `gnark:",public"`
+
+	// Nonce is the challenge every user signs, to prove liveness of the
+	// key backing their balance.
+	Nonce frontend.Variable `gnark:",public"`
+
+	// PubKeys, Signatures, Index and Proofs are the per-user private
+	// witness: PubKeys[i]/Signatures[i] authenticate user i, Index[i] is
+	// their leaf index, and Proofs[i] is their MerkleSum inclusion proof.
+	PubKeys    []eddsa.PublicKey
+	Signatures []eddsa.Signature
+	Index      []frontend.Variable
+	Proofs     []MerkleSumProof
+}
+
+// NewSolvencyCircuit allocates a SolvencyCircuit for a batch of k users,
+// whose balances sit in a Merkle sum tree of the given depth and whose
+// balances are constrained to balanceBits bits.
+func NewSolvencyCircuit(k, depth, balanceBits int) SolvencyCircuit {
+	circuit := SolvencyCircuit{
+		PubKeys:    make([]eddsa.PublicKey, k),
+		Signatures: make([]eddsa.Signature, k),
+		Index:      make([]frontend.Variable, k),
+		Proofs:     make([]MerkleSumProof, k),
+	}
+	for i := range circuit.Proofs {
+		circuit.Proofs[i] = NewMerkleSumProof(depth, balanceBits)
+	}
+	return circuit
+}
+
+// Define declares the SolvencyCircuit constraints.
+func (circuit *SolvencyCircuit) Define(api frontend.API) error {
+
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+
+	for i := range circuit.Proofs {
+
+		if err := eddsa.Verify(api, circuit.Signatures[i], circuit.Nonce, circuit.PubKeys[i], &h); err != nil {
+			return err
+		}
+
+		// the leaf stored in the tree must be the hash of the very key that
+		// just signed the nonce, so a user cannot be proven solvent under
+		// someone else's balance.
+		pubKeyHash := nodeHash(api, &h, circuit.PubKeys[i].A.X, circuit.PubKeys[i].A.Y)
+		api.AssertIsEqual(circuit.Proofs[i].PathHash[0], pubKeyHash)
+
+		circuit.Proofs[i].RootHash = circuit.RootHash
+		circuit.Proofs[i].RootSum = circuit.RootSum
+		circuit.Proofs[i].VerifyProof(api, &h, Leaf{data: circuit.Index[i]})
+	}
+
+	return nil
+}
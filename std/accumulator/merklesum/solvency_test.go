@@ -0,0 +1,83 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merklesum
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	eddsaBN256 "github.com/consensys/gnark/crypto/signature/eddsa/bn256"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/std/accumulator/merklesum/plain"
+	"github.com/consensys/gnark/test"
+)
+
+// TestSolvencyVerify exercises the EdDSA signature check, the MerkleSum
+// inclusion proof and the balance range check of SolvencyCircuit together,
+// end to end: build a tree of accounts, sign a nonce with each account's
+// key, and check that the circuit accepts the resulting witness.
+func TestSolvencyVerify(t *testing.T) {
+
+	assert := test.NewAssert(t)
+	const (
+		k           = 4
+		depth       = 2 // numLeaves == 2^depth == k
+		balanceBits = 64
+		segmentSize = 32
+		nonce       = uint64(42)
+		curveID     = ecc.BN254
+	)
+
+	accounts := make([]plain.Account, k)
+	privateKeys := make([]eddsaBN256.PrivateKey, k)
+	for i := 0; i < k; i++ {
+		var seed [32]byte
+		_, err := rand.Read(seed[:])
+		assert.NoError(err)
+
+		pub, priv := eddsaBN256.GenerateKey(seed)
+		balance, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), balanceBits))
+		assert.NoError(err)
+
+		accounts[i] = plain.Account{PublicKey: pub, Balance: balance.Uint64()}
+		privateKeys[i] = priv
+	}
+
+	root, data, sums, err := plain.BuildTree(curveID, accounts, balanceBits, segmentSize)
+	assert.NoError(err)
+
+	witness, err := plain.BuildWitness(curveID, accounts, privateKeys, nonce, depth, balanceBits, data, sums, segmentSize)
+	assert.NoError(err)
+	witness.RootHash = root.Hash
+	witness.RootSum = root.Sum
+
+	circuit := NewSolvencyCircuit(k, depth, balanceBits)
+	ccs, err := frontend.Compile(curveID.ScalarField(), r1cs.NewBuilder, &circuit)
+	assert.NoError(err)
+
+	w, err := frontend.NewWitness(witness, curveID.ScalarField())
+	assert.NoError(err)
+
+	err = ccs.IsSolved(w, backend.IgnoreSolverError())
+	assert.NoError(err)
+
+	assert.SolvingSucceeded(&circuit, witness, test.WithCurves(curveID))
+}
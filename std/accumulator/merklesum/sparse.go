@@ -0,0 +1,109 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merklesum
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash"
+)
+
+// SparseMerkleSumProof is a MerkleSumProof for a tree keyed by a fixed-width
+// identifier (e.g. H(userID)) rather than by dense leaf index: every one of
+// the 2^depth possible keys has a position in the tree, most of them rooted
+// in an "empty" subtree. This lets a verifier check not only that a key is
+// present with a given balance, but also that a key is absent.
+type SparseMerkleSumProof struct {
+
+	// RootHash, RootSum is the root of the sparse Merkle sum tree.
+	RootHash, RootSum frontend.Variable
+
+	// PathHash, PathSum is the sibling at each level on the path from the
+	// leaf (index 0) to the root (index depth), exactly like MerkleSumProof.
+	PathHash, PathSum []frontend.Variable
+
+	// emptyHash, emptySum hold, for every depth from the leaf (index 0) up
+	// to the root (index depth), the hash/sum of the empty subtree rooted
+	// at that depth. They are public constants of the hash function used,
+	// precomputed once in plain Go.
+	emptyHash, emptySum []frontend.Variable
+
+	balanceBits int
+}
+
+// NewSparseMerkleSumProof allocates a SparseMerkleSumProof for a tree of the
+// given depth, constraining balances to balanceBits bits. emptyHash and
+// emptySum must hold depth+1 precomputed empty-subtree constants, as
+// returned by the plain package's EmptySubtreeConstants.
+func NewSparseMerkleSumProof(depth, balanceBits int, emptyHash, emptySum []frontend.Variable) SparseMerkleSumProof {
+	return SparseMerkleSumProof{
+		PathHash:    make([]frontend.Variable, depth+1),
+		PathSum:     make([]frontend.Variable, depth+1),
+		emptyHash:   emptyHash,
+		emptySum:    emptySum,
+		balanceBits: balanceBits,
+	}
+}
+
+// VerifyProof walks key, a depth-bit identifier, from its most significant
+// bit down to its least significant bit, recomputing the root from
+// PathHash[0]/PathSum[0] (the leaf) and the siblings in PathHash/PathSum.
+// Level i's sibling (PathHash[i+1]/PathSum[i+1]) is checked against the
+// precomputed empty-subtree constants: a sibling can only present itself as
+// the canonical empty subtree (by matching its hash) if it also carries the
+// empty subtree's zero sum, so a pruned branch can never hide a balance.
+func (mp *SparseMerkleSumProof) VerifyProof(api frontend.API, h hash.Hash, key frontend.Variable) {
+
+	depth := len(mp.PathHash) - 1
+
+	// keyBits is little-endian (keyBits[0] is the key's least significant
+	// bit). The loop climbs from the leaf to the root, so it must consume
+	// the key's most significant bit first: keyBits[depth-1-i] at level i.
+	keyBits := api.ToBinary(key, depth)
+
+	api.ToBinary(mp.PathSum[0], mp.balanceBits)
+	hash := mp.PathHash[0]
+	sum := mp.PathSum[0]
+
+	for i := 0; i < depth; i++ {
+		bit := keyBits[depth-1-i]
+		d1 := api.Select(bit, mp.PathHash[i+1], hash)
+		d2 := api.Select(bit, hash, mp.PathHash[i+1])
+		hash = nodeHash(api, h, d1, d2)
+
+		// PathSum[i+1] is the sibling subtree's sum over its 2^i leaves.
+		api.ToBinary(mp.PathSum[i+1], mp.balanceBits+i)
+		sum = nodeSum(api, sum, mp.PathSum[i+1])
+		api.ToBinary(sum, mp.balanceBits+i+1)
+
+		isEmptySibling := api.IsZero(api.Sub(mp.PathHash[i+1], mp.emptyHash[i]))
+		api.AssertIsEqual(api.Mul(isEmptySibling, api.Sub(mp.PathSum[i+1], mp.emptySum[i])), 0)
+	}
+
+	api.AssertIsEqual(hash, mp.RootHash)
+	api.AssertIsEqual(sum, mp.RootSum)
+}
+
+// VerifyNonMembership proves that key is absent from the tree: the leaf
+// PathHash/PathSum must be exactly the empty-leaf constants, and the rest of
+// the path must still reconstruct the published root.
+func (mp *SparseMerkleSumProof) VerifyNonMembership(api frontend.API, h hash.Hash, key frontend.Variable) {
+
+	api.AssertIsEqual(mp.PathHash[0], mp.emptyHash[0])
+	api.AssertIsEqual(mp.PathSum[0], mp.emptySum[0])
+
+	mp.VerifyProof(api, h, key)
+}
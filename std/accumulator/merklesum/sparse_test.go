@@ -0,0 +1,157 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merklesum
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	gcHash "github.com/consensys/gnark-crypto/hash"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/accumulator/merklesum/plain"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/test"
+)
+
+const sparseDepth = 3
+const sparseBalanceBits = 64
+
+// SparseMerkleProofTest used for testing only
+type SparseMerkleProofTest struct {
+	M             SparseMerkleSumProof
+	Key           frontend.Variable
+	NonMembership bool
+}
+
+func (mp *SparseMerkleProofTest) Define(api frontend.API) error {
+
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+
+	if mp.NonMembership {
+		mp.M.VerifyNonMembership(api, &h, mp.Key)
+	} else {
+		mp.M.VerifyProof(api, &h, mp.Key)
+	}
+
+	return nil
+}
+
+// TestSparseVerifyNonMembership checks that a key whose leaf is the
+// empty-subtree constant, and whose path otherwise matches the empty tree,
+// is accepted as absent.
+func TestSparseVerifyNonMembership(t *testing.T) {
+
+	assert := test.NewAssert(t)
+	curveID := ecc.BN254
+
+	hFunc := gcHash.MIMC_BN254.New()
+	emptyHash, emptySum := plain.EmptySubtreeConstants(curveID, hFunc, sparseDepth)
+
+	emptyHashVars := make([]frontend.Variable, sparseDepth+1)
+	emptySumVars := make([]frontend.Variable, sparseDepth+1)
+	for i := range emptyHashVars {
+		emptyHashVars[i] = emptyHash[i]
+		emptySumVars[i] = emptySum[i]
+	}
+
+	var circuit SparseMerkleProofTest
+	circuit.M = NewSparseMerkleSumProof(sparseDepth, sparseBalanceBits, emptyHashVars, emptySumVars)
+	circuit.NonMembership = true
+
+	var witness SparseMerkleProofTest
+	witness.M = NewSparseMerkleSumProof(sparseDepth, sparseBalanceBits, emptyHashVars, emptySumVars)
+	witness.NonMembership = true
+	witness.Key = big.NewInt(5)
+	witness.M.RootHash = emptyHash[sparseDepth]
+	witness.M.RootSum = emptySum[sparseDepth]
+	// PathHash[0]/PathSum[0] is the empty leaf; PathHash[i]/PathSum[i] for
+	// i>0 is the sibling subtree one level down, i.e. of depth i-1.
+	witness.M.PathHash[0] = emptyHash[0]
+	witness.M.PathSum[0] = emptySum[0]
+	for i := 1; i <= sparseDepth; i++ {
+		witness.M.PathHash[i] = emptyHash[i-1]
+		witness.M.PathSum[i] = emptySum[i-1]
+	}
+
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(curveID))
+}
+
+// TestSparseVerifyMembership checks inclusion of the single non-empty key in
+// an otherwise empty tree: every sibling encountered while climbing from its
+// leaf must therefore be the empty-subtree constant for that level.
+func TestSparseVerifyMembership(t *testing.T) {
+
+	assert := test.NewAssert(t)
+	curveID := ecc.BN254
+	modNbBytes := len(curveID.ScalarField().Bytes())
+	const key = 5 // arbitrary, in [0, 2^sparseDepth)
+
+	hFunc := gcHash.MIMC_BN254.New()
+	emptyHash, emptySum := plain.EmptySubtreeConstants(curveID, hFunc, sparseDepth)
+
+	emptyHashVars := make([]frontend.Variable, sparseDepth+1)
+	emptySumVars := make([]frontend.Variable, sparseDepth+1)
+	for i := range emptyHashVars {
+		emptyHashVars[i] = emptyHash[i]
+		emptySumVars[i] = emptySum[i]
+	}
+
+	pathHash := make([]*big.Int, sparseDepth+1)
+	pathSum := make([]*big.Int, sparseDepth+1)
+	pathHash[0], pathSum[0] = big.NewInt(123), big.NewInt(77)
+	for i := 1; i <= sparseDepth; i++ {
+		pathHash[i], pathSum[i] = emptyHash[i-1], emptySum[i-1]
+	}
+
+	// recompute the root exactly as VerifyProof does: MSB-to-LSB, sibling
+	// on the right when the bit is 0, on the left when it is 1.
+	hash, sum := pathHash[0], pathSum[0]
+	for i := 0; i < sparseDepth; i++ {
+		bit := (key >> uint(sparseDepth-1-i)) & 1
+		left, right := hash, pathHash[i+1]
+		if bit == 1 {
+			left, right = pathHash[i+1], hash
+		}
+		hFunc.Reset()
+		lb, rb := left.Bytes(), right.Bytes()
+		hFunc.Write(make([]byte, modNbBytes-len(lb)))
+		hFunc.Write(lb)
+		hFunc.Write(make([]byte, modNbBytes-len(rb)))
+		hFunc.Write(rb)
+		hash = new(big.Int).SetBytes(hFunc.Sum(nil))
+		sum = new(big.Int).Add(sum, pathSum[i+1])
+	}
+
+	var circuit SparseMerkleProofTest
+	circuit.M = NewSparseMerkleSumProof(sparseDepth, sparseBalanceBits, emptyHashVars, emptySumVars)
+
+	var witness SparseMerkleProofTest
+	witness.M = NewSparseMerkleSumProof(sparseDepth, sparseBalanceBits, emptyHashVars, emptySumVars)
+	witness.Key = key
+	witness.M.RootHash = hash
+	witness.M.RootSum = sum
+	for i := 0; i <= sparseDepth; i++ {
+		witness.M.PathHash[i] = pathHash[i]
+		witness.M.PathSum[i] = pathSum[i]
+	}
+
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(curveID))
+}
@@ -58,6 +58,29 @@ type MerkleSumProof struct {
 
 	// Path path of the Merkle proof
 	PathHash, PathSum []frontend.Variable
+
+	// balanceBits is the bit-width the leaf balance PathSum[0] is
+	// constrained to fit in; at level i the sibling PathSum[i] is bounded
+	// to balanceBits+i-1 bits and the running sum to balanceBits+i bits,
+	// so that a leaf balance can never be a negative field element or make
+	// the aggregated sum wrap around the scalar field.
+	balanceBits int
+}
+
+// NewMerkleSumProof allocates a MerkleSumProof for a tree of the given depth,
+// constraining every leaf balance to fit in balanceBits bits (and the
+// running sum at level i of the path to balanceBits+i bits).
+//
+// balanceBits is enforced here, in-circuit; gnark-crypto's
+// merkleSum.BuildReaderProof itself has no equivalent range check, so
+// off-circuit callers must reject out-of-range balances themselves before
+// handing them to it (plain.BuildTree does this for Proof-of-Solvency trees).
+func NewMerkleSumProof(depth, balanceBits int) MerkleSumProof {
+	return MerkleSumProof{
+		PathHash:    make([]frontend.Variable, depth+1),
+		PathSum:     make([]frontend.Variable, depth+1),
+		balanceBits: balanceBits,
+	}
 }
 
 type Leaf struct {
@@ -101,6 +124,12 @@ func (mp *MerkleSumProof) VerifyProof(api frontend.API, h hash.Hash, leaf Leaf)
 
 	depth := len(mp.PathHash) - 1
 	hash := leafHash(api, h, mp.PathHash[0])
+
+	// A leaf balance must be a small non-negative integer: range-checking it
+	// to balanceBits bits rules out a prover injecting a negative field
+	// element (i.e. p - balance) to cancel out real liabilities elsewhere in
+	// the tree.
+	api.ToBinary(mp.PathSum[0], mp.balanceBits)
 	sum := mp.PathSum[0]
 
 	// The binary decomposition is the bitwise negation of the order of hashes ->
@@ -112,7 +141,15 @@ func (mp *MerkleSumProof) VerifyProof(api frontend.API, h hash.Hash, leaf Leaf)
 		d1 := api.Select(binLeaf[i-1], mp.PathHash[i], hash)
 		d2 := api.Select(binLeaf[i-1], hash, mp.PathHash[i])
 		hash = nodeHash(api, h, d1, d2)
-		sum = nodeSum(api,sum, mp.PathSum[i])
+
+		// PathSum[i] is the sibling subtree's sum over its 2^(i-1) leaves,
+		// so it can be up to balanceBits+(i-1) bits, not just balanceBits.
+		api.ToBinary(mp.PathSum[i], mp.balanceBits+i-1)
+		sum = nodeSum(api, sum, mp.PathSum[i])
+		// The running sum grows by at most one bit per level, so
+		// bounding it to balanceBits+i bits here makes a modulo-p wrap-around
+		// of the aggregate impossible.
+		api.ToBinary(sum, mp.balanceBits+i)
 	}
 
 	// Compare our calculated Merkle root to the desired Merkle root.
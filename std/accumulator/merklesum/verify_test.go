@@ -28,6 +28,7 @@ import (
 	"github.com/consensys/gnark/logger"
 	"github.com/consensys/gnark/std/hash/mimc"
 	"github.com/consensys/gnark/test"
+	"math/big"
 	"os"
 	"testing"
 )
@@ -55,6 +56,7 @@ func TestVerify(t *testing.T) {
 	assert := test.NewAssert(t)
 	numLeaves := 32
 	depth := 5
+	balanceBits := 64
 
 	type testData struct {
 		hash        hash.Hash
@@ -70,8 +72,7 @@ func TestVerify(t *testing.T) {
 
 		// create the circuit
 		var circuit MerkleProofTest
-		circuit.M.PathHash = make([]frontend.Variable, depth+1)
-		circuit.M.PathSum = make([]frontend.Variable, depth+1)
+		circuit.M = NewMerkleSumProof(depth, balanceBits)
 		cc, err := frontend.Compile(tData.curve.ScalarField(), r1cs.NewBuilder, &circuit)
 		if err != nil {
 			t.Fatal(err)
@@ -93,9 +94,12 @@ func TestVerify(t *testing.T) {
 				buf1.Write(b)
 			}
 
+			// balances must fit in balanceBits bits, or VerifyProof's range
+			// checks will reject the proof.
 			var buf2 bytes.Buffer
+			maxBalance := new(big.Int).Lsh(big.NewInt(1), uint(balanceBits))
 			for i := 0; i < numLeaves; i++ {
-				leaf, err := rand.Int(rand.Reader, mod)
+				leaf, err := rand.Int(rand.Reader, maxBalance)
 				assert.NoError(err)
 				b := leaf.Bytes()
 				buf2.Write(make([]byte, modNbBytes-len(b)))
@@ -119,10 +123,9 @@ func TestVerify(t *testing.T) {
 			// witness
 			var witness MerkleProofTest
 			witness.Index = proofIndex
+			witness.M = NewMerkleSumProof(depth, balanceBits)
 			witness.M.RootHash = merkleRoot.Hash
 			witness.M.RootSum = merkleRoot.Sum
-			witness.M.PathHash = make([]frontend.Variable, depth+1)
-			witness.M.PathSum = make([]frontend.Variable, depth+1)
 			for i := 0; i < depth+1; i++ {
 				witness.M.PathHash[i] = proofPath.Hash[i]
 				witness.M.PathSum[i] = proofPath.Sum[i]